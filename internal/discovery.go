@@ -0,0 +1,79 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package internal defines core data types used throughout the discovery
+// site.
+package internal
+
+import (
+	"time"
+
+	"golang.org/x/discovery/internal/license"
+)
+
+// VersionType is the type of version, as described in
+// https://golang.org/cmd/go/#hdr-Module_queries.
+type VersionType string
+
+const (
+	VersionTypeRelease    VersionType = "release"
+	VersionTypePrerelease VersionType = "prerelease"
+	VersionTypePseudo     VersionType = "pseudo"
+)
+
+// String returns the string for the VersionType.
+func (vt VersionType) String() string {
+	return string(vt)
+}
+
+// VersionInfo holds metadata associated with a module version, independent
+// of any one package in that version.
+type VersionInfo struct {
+	ModulePath     string
+	Version        string
+	CommitTime     time.Time
+	ReadmeFilePath string
+	ReadmeContents []byte
+	VersionType    VersionType
+
+	// Retracted reports whether this version falls within a range retracted
+	// by a `retract` directive in its module's go.mod, as recorded in the
+	// retractions table populated during worker ingest.
+	Retracted bool
+
+	// RetractionRationale is the rationale given by the `retract` directive
+	// that covers this version, if any. It is only meaningful when
+	// Retracted is true.
+	RetractionRationale string
+}
+
+// Package holds package-level metadata.
+type Package struct {
+	Name              string
+	Path              string
+	Synopsis          string
+	Licenses          []*license.Metadata
+	Suffix            string
+	DocumentationHTML []byte
+}
+
+// VersionedPackage is a Package along with the version info for the module
+// version it belongs to.
+type VersionedPackage struct {
+	Package
+	VersionInfo
+}
+
+// Version is a single version of a module, along with all of the packages
+// it contains.
+type Version struct {
+	VersionInfo
+	Packages []*Package
+}
+
+// Import is a package imported by another package.
+type Import struct {
+	Name string
+	Path string
+}
@@ -0,0 +1,144 @@
+// Copyright 2019-2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+// +build ignore
+
+// gen_script_hashes walks static/**/*.tmpl, computes the sha256 of every
+// inline <script> body, and writes the Go literal consumed by SecureHeaders
+// to script_hashes.go. Run it via `go generate` from this directory.
+//
+// With -check, it does not write script_hashes.go; instead it compares the
+// hashes it computes against the committed file and exits non-zero if they
+// differ, so CI fails when a template gains an inline script whose hash
+// hasn't been committed via `go generate`.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// inlineScriptRE matches every <script>...</script> tag; srcAttrRE picks out
+// those with a src attribute, which load external files and don't need a hash.
+var inlineScriptRE = regexp.MustCompile(`(?is)<script(?:\s[^>]*)?>(.*?)</script>`)
+var srcAttrRE = regexp.MustCompile(`(?is)<script\s[^>]*\bsrc\s*=`)
+
+const staticDir = "../../static"
+
+func main() {
+	check := flag.Bool("check", false, "verify script_hashes.go is up to date instead of writing it")
+	flag.Parse()
+
+	var tmpls []string
+	err := filepath.Walk(staticDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".tmpl" {
+			tmpls = append(tmpls, path)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("filepath.Walk(%q): %v", staticDir, err)
+	}
+
+	type hash struct {
+		comment, literal string
+	}
+	var hashes []hash
+	for _, path := range tmpls {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Fatalf("ioutil.ReadFile(%q): %v", path, err)
+		}
+		rel, err := filepath.Rel(filepath.Dir(staticDir), path)
+		if err != nil {
+			log.Fatalf("filepath.Rel: %v", err)
+		}
+		for _, m := range inlineScriptRE.FindAllSubmatch(contents, -1) {
+			tag := m[0]
+			if srcAttrRE.Match(tag) {
+				continue
+			}
+			body := m[1]
+			if len(bytes.TrimSpace(body)) == 0 {
+				continue
+			}
+			sum := sha256.Sum256(body)
+			literal := fmt.Sprintf("'sha256-%s='", base64.StdEncoding.EncodeToString(sum[:]))
+			hashes = append(hashes, hash{comment: rel, literal: literal})
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		if hashes[i].comment != hashes[j].comment {
+			return hashes[i].comment < hashes[j].comment
+		}
+		return hashes[i].literal < hashes[j].literal
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprint(&buf, header)
+	lastComment := ""
+	for _, h := range hashes {
+		if h.comment != lastComment {
+			fmt.Fprintf(&buf, "\t// From %s\n", h.comment)
+			lastComment = h.comment
+		}
+		fmt.Fprintf(&buf, "\t%q,\n", h.literal)
+	}
+	fmt.Fprint(&buf, footer)
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("format.Source: %v", err)
+	}
+
+	if *check {
+		committed, err := ioutil.ReadFile("script_hashes.go")
+		if err != nil {
+			log.Fatalf("ioutil.ReadFile(%q): %v", "script_hashes.go", err)
+		}
+		if !bytes.Equal(committed, out) {
+			log.Fatal("script_hashes.go is out of date: a static/**/*.tmpl inline <script> " +
+				"hash is missing or stale; run `go generate` in internal/middleware and commit the result")
+		}
+		return
+	}
+
+	if err := ioutil.WriteFile("script_hashes.go", out, 0644); err != nil {
+		log.Fatalf("ioutil.WriteFile: %v", err)
+	}
+}
+
+const header = `// Copyright 2019-2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code generated by go generate; DO NOT EDIT.
+// To regenerate, run ` + "`go generate`" + ` from this directory.
+
+package middleware
+
+// scriptHashes lists the sha256 hashes, in CSP 'sha256-...' form, of every
+// inline <script> body found under static/**/*.tmpl. It is generated by
+// gen_script_hashes.go and used in the script-src directive built by
+// SecureHeaders; running gen_script_hashes.go with -check fails if a
+// template contains an inline script whose hash is missing here.
+var scriptHashes = []string{
+`
+
+const footer = `}
+`
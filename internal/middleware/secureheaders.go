@@ -5,55 +5,48 @@
 package middleware
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 )
 
-var scriptHashes = []string{
-	// From static/frontend/fetch/fetch.tmpl
-	"'sha256-DVdvl49HC0iGx/YKQq/kVNATnEdzGfExbJVTHqT95l8='",
-	// From static/frontend/frontend.tmpl
-	"'sha256-CoGrkqEM1Kjjf5b1bpcnDLl8ZZLAsVX+BoAzZ5+AOmc='",
-	"'sha256-Rex7jo7NdAFHm6IM8u1LgCIn9Gr9p2QZ0bf6ZkK618g='",
-	"'sha256-karKh1IrXOF1g+uoSxK+k9BuciCwYY/ytGuQVUiRzcM='",
-	// From static/frontend/styleguide/styleguide.tmpl
-	"'sha256-bL+cN9GtUg5dqjPwDiPJq4yfiEvOyEJ3rfw/YkNIAWc='",
-	// From static/frontend/unit/main/main.tmpl
-	"'sha256-UiVwSVJIK9udADqG5GZe+nRUXWK9wEot2vrxL4D2pQs='",
-	// From static/frontend/unit/unit.tmpl
-	"'sha256-cB+y/oSfWGFf7lHk8KX+ZX2CZQz/dPamIICuPvHcB6w='",
-	// From static/frontend/unit/versions/versions.tmpl
-	"'sha256-7mi5SPcD1cogj2+ju8J/+/qJG99F6Qo+3pO4xQkRf6Q='",
-	// From static/legacy/html/pages/unit.tmpl
-	"'sha256-V0I0c9gVBohHALcsk23X2c1nd3GO+Kpc1BNCpLhEj7Y='",
-	// From static/legacy/html/pages/unit_details.tmpl
-	"'sha256-bHZGfbft0NNI4pr8JS2ajCVFIrvcY1o07hbUL2Lfdls='",
-	"'sha256-NgMe1ssApnbzZAEDkxSBAFfCNRfW6F7ajTmp08jUrPI='",
-	"'sha256-lK9quwyQtvjVXRYCc2nYBfam6X9NN7FitPdCEVd3wpE='",
-	// From static/legacy/html/pages/unit_versions.tmpl
-	"'sha256-86HQcJ6uexGUBJWyPdp/1pozG9N7B3EUGT0ooKXwWzY='",
-	// From static/worker/index.tmpl
-	"'sha256-rEbn/zvLCsDDvDrVWQuUkKGEQsjQjFvIvJK4NVIMqZ4='",
+//go:generate go run gen_script_hashes.go
+
+// cspReportGroup is the Reporting API endpoint group name that the
+// report-to CSP directive refers to.
+const cspReportGroup = "csp-endpoint"
+
+// SecureHeadersConfig configures the headers added by SecureHeaders.
+type SecureHeadersConfig struct {
+	// StrictMode, when true, omits 'unsafe-inline' from the enforced
+	// script-src directive. Browsers that support 'strict-dynamic' already
+	// ignore 'unsafe-inline' when both are present, but older browsers fall
+	// back to 'unsafe-inline' and will execute unhashed inline scripts
+	// today; StrictMode is for once an operator has verified, via the
+	// report-only policy below, that no such scripts remain.
+	StrictMode bool
+
+	// ReportURI, if non-empty, is where CSPReportHandler is served and is
+	// registered as the report-uri/report-to endpoint for a
+	// Content-Security-Policy-Report-Only header that always previews the
+	// strict policy, regardless of StrictMode, so operators can stage a
+	// rollout before flipping StrictMode on.
+	ReportURI string
 }
 
 // SecureHeaders adds a content-security-policy and other security-related
 // headers to all responses.
-func SecureHeaders(enableCSP bool) Middleware {
+func SecureHeaders(enableCSP bool, cfg SecureHeadersConfig) Middleware {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			csp := []string{
-				// Disallow plugin content: pkg.go.dev does not use it.
-				"object-src 'none'",
-				// Disallow <base> URIs, which prevents attackers from changing the
-				// locations of scripts loaded from relative URLs. The site doesn’t have
-				// a <base> tag anyway.
-				"base-uri 'none'",
-				fmt.Sprintf("script-src 'unsafe-inline' 'strict-dynamic' https: http: %s",
-					strings.Join(scriptHashes, " ")),
-			}
 			if enableCSP {
-				w.Header().Set("Content-Security-Policy", strings.Join(csp, "; "))
+				w.Header().Set("Content-Security-Policy", buildCSP(cfg.StrictMode, ""))
+				if cfg.ReportURI != "" {
+					w.Header().Set("Report-To", reportToHeader(cfg.ReportURI))
+					w.Header().Set("Content-Security-Policy-Report-Only", buildCSP(true, cfg.ReportURI))
+				}
 			}
 			// Don't allow frame embedding.
 			w.Header().Set("X-Frame-Options", "deny")
@@ -64,3 +57,91 @@ func SecureHeaders(enableCSP bool) Middleware {
 		})
 	}
 }
+
+// buildCSP assembles the Content-Security-Policy header value. When strict
+// is false, 'unsafe-inline' is included alongside 'strict-dynamic' for
+// browsers that don't support the latter. When reportURI is non-empty, the
+// policy additionally reports violations to cspReportGroup.
+func buildCSP(strict bool, reportURI string) string {
+	unsafeInline := "'unsafe-inline' "
+	if strict {
+		unsafeInline = ""
+	}
+	csp := []string{
+		// Disallow plugin content: pkg.go.dev does not use it.
+		"object-src 'none'",
+		// Disallow <base> URIs, which prevents attackers from changing the
+		// locations of scripts loaded from relative URLs. The site doesn’t have
+		// a <base> tag anyway.
+		"base-uri 'none'",
+		fmt.Sprintf("script-src %s'strict-dynamic' https: http: %s",
+			unsafeInline, strings.Join(scriptHashes, " ")),
+	}
+	if reportURI != "" {
+		csp = append(csp,
+			fmt.Sprintf("report-uri %s", reportURI),
+			fmt.Sprintf("report-to %s", cspReportGroup))
+	}
+	return strings.Join(csp, "; ")
+}
+
+// reportToHeader builds the value of the Report-To header, which registers
+// the endpoint group that the report-to CSP directive references. See
+// https://www.w3.org/TR/reporting-1/.
+func reportToHeader(reportURI string) string {
+	b, err := json.Marshal(struct {
+		Group     string `json:"group"`
+		MaxAge    int    `json:"max_age"`
+		Endpoints []struct {
+			URL string `json:"url"`
+		} `json:"endpoints"`
+	}{
+		Group:  cspReportGroup,
+		MaxAge: 10886400,
+		Endpoints: []struct {
+			URL string `json:"url"`
+		}{{URL: reportURI}},
+	})
+	if err != nil {
+		// The input is a fixed shape with no user-controlled types.
+		panic(err)
+	}
+	return string(b)
+}
+
+// cspReport is the JSON body browsers POST to the CSP report-uri/report-to
+// endpoint. See https://www.w3.org/TR/CSP3/#deprecated-serialize-violation.
+type cspReport struct {
+	CSPReport struct {
+		DocumentURI        string `json:"document-uri"`
+		Referrer           string `json:"referrer"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		BlockedURI         string `json:"blocked-uri"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+	} `json:"csp-report"`
+}
+
+// maxCSPReportBytes bounds how much of a POSTed csp-report body
+// CSPReportHandler will read. It is a public, unauthenticated endpoint, and
+// a real violation report is a few hundred bytes, so this is generous
+// headroom rather than a tight fit.
+const maxCSPReportBytes = 16 << 10 // 16 KiB
+
+// CSPReportHandler logs Content-Security-Policy violation reports posted by
+// browsers to the endpoint configured as SecureHeadersConfig.ReportURI, so
+// operators can find the scripts StrictMode would otherwise block before
+// enabling it.
+func CSPReportHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCSPReportBytes)
+	var report cspReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		http.Error(w, "invalid csp-report body", http.StatusBadRequest)
+		return
+	}
+	log.Printf("CSP violation: document=%s blocked=%q directive=%q source=%s:%d",
+		report.CSPReport.DocumentURI, report.CSPReport.BlockedURI,
+		report.CSPReport.EffectiveDirective, report.CSPReport.SourceFile, report.CSPReport.LineNumber)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -145,7 +145,7 @@ func (db *DB) GetLatestPackage(ctx context.Context, path string) (*internal.Vers
 			v.major DESC,
 			v.minor DESC,
 			v.patch DESC,
-			v.prerelease DESC
+			v.prerelease_sort DESC
 		LIMIT 1;`
 
 	row := db.QueryRowContext(ctx, query, path)
@@ -267,29 +267,56 @@ func (db *DB) GetVersionForPackage(ctx context.Context, path, version string) (*
 }
 
 // GetTaggedVersionsForPackageSeries returns a list of tagged versions sorted
-// in descending order by major, minor and patch number and then lexicographically
-// in descending order by prerelease. This list includes tagged versions of
+// in descending order by major, minor and patch number and then by semver
+// prerelease precedence. This list includes tagged versions of
 // packages that are part of the same series and have the same package suffix.
 func (db *DB) GetTaggedVersionsForPackageSeries(ctx context.Context, path string) ([]*internal.VersionInfo, error) {
-	return getVersions(ctx, db, path, []internal.VersionType{internal.VersionTypeRelease, internal.VersionTypePrerelease})
+	return getVersions(ctx, db, path, []internal.VersionType{internal.VersionTypeRelease, internal.VersionTypePrerelease}, versionFilter{})
 }
 
 // GetPseudoVersionsForPackageSeries returns the 10 most recent from a list of
 // pseudo-versions sorted in descending order by major, minor and patch number
-// and then lexicographically in descending order by prerelease. This list includes
+// and then by semver prerelease precedence. This list includes
 // pseudo-versions of packages that are part of the same series and have the same
 // package suffix.
 func (db *DB) GetPseudoVersionsForPackageSeries(ctx context.Context, path string) ([]*internal.VersionInfo, error) {
-	return getVersions(ctx, db, path, []internal.VersionType{internal.VersionTypePseudo})
+	return getVersions(ctx, db, path, []internal.VersionType{internal.VersionTypePseudo}, versionFilter{})
+}
+
+// GetPatchVersionsForPackageSeries returns the list of tagged versions in the
+// same major.minor series as currentVersion, excluding any version retracted
+// by its module's go.mod. This answers the same question as `go list -m
+// -versions`, restricted to the series the caller is already on.
+func (db *DB) GetPatchVersionsForPackageSeries(ctx context.Context, path, currentVersion string) ([]*internal.VersionInfo, error) {
+	return getVersions(ctx, db, path,
+		[]internal.VersionType{internal.VersionTypeRelease, internal.VersionTypePrerelease},
+		versionFilter{seriesVersion: currentVersion, excludeRetracted: true})
+}
+
+// versionFilter narrows the results of getVersions beyond VersionType.
+type versionFilter struct {
+	// seriesVersion, when non-empty, restricts results to versions in the
+	// same major.minor series as this version (e.g. "v1.2.0" matches only
+	// "v1.2.x" versions).
+	seriesVersion string
+
+	// excludeRetracted, when true, omits versions retracted by a `retract`
+	// directive in their module's go.mod. When false, retracted versions
+	// are still included, but retractions are not looked up at all, so
+	// VersionInfo.Retracted and VersionInfo.RetractionRationale are left
+	// unset on every result.
+	excludeRetracted bool
 }
 
 // getVersions returns a list of versions sorted numerically
-// in descending order by major, minor and patch number and then
-// lexicographically in descending order by prerelease. The version types
+// in descending order by major, minor and patch number and then by
+// semver prerelease precedence. The version types
 // included in the list are specified by a list of VersionTypes. The results
 // include the type of versions of packages that are part of the same series
 // and have the same package suffix as the package specified by the path.
-func getVersions(ctx context.Context, db *DB, path string, versionTypes []internal.VersionType) ([]*internal.VersionInfo, error) {
+// filter narrows the results further, to a major.minor series and/or past
+// retracted versions; see versionFilter.
+func getVersions(ctx context.Context, db *DB, path string, versionTypes []internal.VersionType, filter versionFilter) ([]*internal.VersionInfo, error) {
 	var (
 		commitTime                                time.Time
 		seriesPath, modulePath, synopsis, version string
@@ -312,6 +339,7 @@ func getVersions(ctx context.Context, db *DB, path string, versionTypes []intern
 				v.minor,
 				v.patch,
 				v.prerelease,
+				v.prerelease_sort,
 				v.version_type
 			FROM
 				modules m
@@ -339,12 +367,13 @@ func getVersions(ctx context.Context, db *DB, path string, versionTypes []intern
 				FROM package_series WHERE package_path=$1
 			)
 			AND (%s)
+			%s
 		ORDER BY
 			module_path DESC,
 			major DESC,
 			minor DESC,
 			patch DESC,
-			prerelease DESC %s`
+			prerelease_sort DESC %s`
 
 	queryEnd := `;`
 	if len(versionTypes) == 0 {
@@ -357,34 +386,81 @@ func getVersions(ctx context.Context, db *DB, path string, versionTypes []intern
 		vtQuery []string
 		params  = []interface{}{path}
 	)
-	for i, vt := range versionTypes {
-		vtQuery = append(vtQuery, fmt.Sprintf(`version_type = $%d`, i+2))
+	for _, vt := range versionTypes {
+		vtQuery = append(vtQuery, fmt.Sprintf(`version_type = $%d`, len(params)+1))
 		params = append(params, vt.String())
 	}
 
-	query := fmt.Sprintf(baseQuery, strings.Join(vtQuery, " OR "), queryEnd)
+	var seriesClause string
+	if filter.seriesVersion != "" {
+		major, minor, err := seriesMajorMinor(filter.seriesVersion)
+		if err != nil {
+			return nil, derrors.InvalidArgument(err.Error())
+		}
+		seriesClause = fmt.Sprintf("AND major = $%d AND minor = $%d", len(params)+1, len(params)+2)
+		params = append(params, major, minor)
+	}
+
+	query := fmt.Sprintf(baseQuery, strings.Join(vtQuery, " OR "), seriesClause, queryEnd)
 
 	rows, err := db.QueryContext(ctx, query, params...)
 	if err != nil {
 		return nil, fmt.Errorf("db.QueryContext(ctx, %q, %q): %v", query, path, err)
 	}
-	defer rows.Close()
 
+	type versionRow struct {
+		modulePath, version, synopsis string
+		commitTime                    time.Time
+	}
+	var versionRows []versionRow
 	for rows.Next() {
 		if err := rows.Scan(&seriesPath, &modulePath, &version, &commitTime, &synopsis); err != nil {
+			rows.Close()
 			return nil, fmt.Errorf("row.Scan(): %v", err)
 		}
-
-		versionHistory = append(versionHistory, &internal.VersionInfo{
-			ModulePath: modulePath,
-			Version:    version,
-			CommitTime: commitTime,
-		})
+		versionRows = append(versionRows, versionRow{modulePath, version, synopsis, commitTime})
 	}
-
 	if err := rows.Err(); err != nil {
+		rows.Close()
 		return nil, fmt.Errorf("rows.Err(): %v", err)
 	}
+	rows.Close()
+
+	// Only fetch retraction data when a caller actually asked for it:
+	// GetTaggedVersionsForPackageSeries and GetPseudoVersionsForPackageSeries
+	// don't set excludeRetracted and shouldn't pay for the extra round trip
+	// or have their results annotated with retraction info they didn't ask
+	// for.
+	var retractionsByModule map[string][]Retraction
+	if filter.excludeRetracted {
+		modulePaths := make([]string, 0, len(versionRows))
+		seen := map[string]bool{}
+		for _, vr := range versionRows {
+			if !seen[vr.modulePath] {
+				seen[vr.modulePath] = true
+				modulePaths = append(modulePaths, vr.modulePath)
+			}
+		}
+		var err error
+		retractionsByModule, err = db.getRetractions(ctx, modulePaths)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, vr := range versionRows {
+		retracted, rationale := isRetracted(vr.version, retractionsByModule[vr.modulePath])
+		if retracted && filter.excludeRetracted {
+			continue
+		}
+		versionHistory = append(versionHistory, &internal.VersionInfo{
+			ModulePath:          vr.modulePath,
+			Version:             vr.version,
+			CommitTime:          vr.commitTime,
+			Retracted:           retracted,
+			RetractionRationale: rationale,
+		})
+	}
 
 	return versionHistory, nil
 }
@@ -0,0 +1,107 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// noPrereleaseSort is the prerelease_sort value for a version with no
+// prerelease; it sorts after any encoded prerelease (see prereleaseSortKey),
+// so that e.g. "v1.0.0" ranks above "v1.0.0-rc.1".
+const noPrereleaseSort = "~"
+
+// numericIdentifierLenDigits is the width of the decimal length prefix
+// numeric identifiers are encoded with; see prereleaseSortKey. Three digits
+// supports identifiers up to 999 digits long, far beyond anything a real
+// go.mod version will contain.
+const numericIdentifierLenDigits = 3
+
+// prereleaseSortKey encodes prerelease as a string that sorts
+// lexicographically in the same order semver.Compare would rank it against
+// other prereleases of the same version, per the precedence rules in semver
+// spec 2.11.1: dot-separated identifiers are compared left to right, numeric
+// identifiers always sort before alphanumeric ones, and numeric identifiers
+// compare by numeric value rather than lexicographically.
+//
+// Numeric identifiers are encoded as their decimal length followed by the
+// digits themselves, rather than parsed into a fixed-width integer: the
+// semver spec places no limit on how many digits a numeric identifier may
+// have, and parsing into e.g. a uint64 would silently misorder identifiers
+// too large to fit. Since semver forbids leading zeroes in numeric
+// identifiers, comparing the length first and then the digits lexically
+// reproduces numeric order for identifiers of any size.
+//
+// Tokens are joined with '!', which sorts before every character an
+// identifier may contain (including '-'), so an identifier that is a prefix
+// of another still sorts first.
+func prereleaseSortKey(prerelease string) string {
+	if prerelease == "" {
+		return noPrereleaseSort
+	}
+	ids := strings.Split(prerelease, ".")
+	tokens := make([]string, len(ids))
+	for i, id := range ids {
+		if isNumericIdentifier(id) {
+			tokens[i] = fmt.Sprintf("0%0*d%s", numericIdentifierLenDigits, len(id), id)
+		} else {
+			tokens[i] = "1" + id
+		}
+	}
+	return strings.Join(tokens, "!")
+}
+
+// isNumericIdentifier reports whether s is a semver numeric prerelease
+// identifier: one or more ASCII digits.
+func isNumericIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// BackfillPrereleaseSort computes and stores prerelease_sort for every row
+// in versions. The migration that introduces the column backfills it in SQL
+// already, so this isn't required after running it; it's idempotent and
+// safe to run repeatedly, for recomputing prerelease_sort if its encoding
+// ever changes.
+func (db *DB) BackfillPrereleaseSort(ctx context.Context) error {
+	rows, err := db.QueryContext(ctx, `SELECT module_path, version, prerelease FROM versions;`)
+	if err != nil {
+		return fmt.Errorf("db.QueryContext(ctx, ...): %v", err)
+	}
+	type row struct{ modulePath, version, prerelease string }
+	var toUpdate []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.modulePath, &r.version, &r.prerelease); err != nil {
+			rows.Close()
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		toUpdate = append(toUpdate, r)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("rows.Err(): %v", err)
+	}
+	rows.Close()
+
+	for _, r := range toUpdate {
+		_, err := db.ExecContext(ctx,
+			`UPDATE versions SET prerelease_sort = $1 WHERE module_path = $2 AND version = $3;`,
+			prereleaseSortKey(r.prerelease), r.modulePath, r.version)
+		if err != nil {
+			return fmt.Errorf("db.ExecContext(ctx, ...): %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import "testing"
+
+// TestPrereleaseSortKeyOrdering checks prereleaseSortKey against the
+// semver.org precedence example chain (spec clause 11):
+// 1.0.0-alpha < 1.0.0-alpha.1 < 1.0.0-alpha.beta < 1.0.0-beta <
+// 1.0.0-beta.2 < 1.0.0-beta.11 < 1.0.0-rc.1 < 1.0.0.
+func TestPrereleaseSortKeyOrdering(t *testing.T) {
+	chain := []string{
+		"alpha",
+		"alpha.1",
+		"alpha.beta",
+		"beta",
+		"beta.2",
+		"beta.11",
+		"rc.1",
+		"", // 1.0.0, no prerelease
+	}
+	var keys []string
+	for _, prerelease := range chain {
+		keys = append(keys, prereleaseSortKey(prerelease))
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			t.Errorf("prereleaseSortKey(%q) = %q, want strictly less than prereleaseSortKey(%q) = %q",
+				chain[i-1], keys[i-1], chain[i], keys[i])
+		}
+	}
+}
+
+// TestPrereleaseSortKeyLargeNumericIdentifier checks that a numeric
+// identifier wider than a uint64 can hold still sorts after a shorter one,
+// instead of silently wrapping to a small value.
+func TestPrereleaseSortKeyLargeNumericIdentifier(t *testing.T) {
+	small := prereleaseSortKey("1")
+	huge := prereleaseSortKey("99999999999999999999") // > math.MaxUint64
+	if small >= huge {
+		t.Errorf("prereleaseSortKey(%q) = %q, want strictly less than prereleaseSortKey(%q) = %q",
+			"1", small, "99999999999999999999", huge)
+	}
+}
+
+func TestIsNumericIdentifier(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"", false},
+		{"0", true},
+		{"123", true},
+		{"beta", false},
+		{"1a", false},
+	}
+	for _, tt := range tests {
+		if got := isNumericIdentifier(tt.in); got != tt.want {
+			t.Errorf("isNumericIdentifier(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"golang.org/x/mod/semver"
+)
+
+// Retraction is a single `retract` range from a module's go.mod, as recorded
+// in the retractions table during worker ingest.
+type Retraction struct {
+	Low, High, Rationale string
+}
+
+// getRetractions returns, for each of modulePaths that has any, the
+// retraction ranges recorded for it. It issues a single query regardless of
+// len(modulePaths), so callers iterating many rows from an unrelated query
+// should collect the distinct module paths they need first rather than
+// calling this once per row.
+func (db *DB) getRetractions(ctx context.Context, modulePaths []string) (map[string][]Retraction, error) {
+	byModule := map[string][]Retraction{}
+	if len(modulePaths) == 0 {
+		return byModule, nil
+	}
+
+	query := `
+		SELECT
+			module_path,
+			low,
+			high,
+			rationale
+		FROM
+			retractions
+		WHERE
+			module_path = ANY($1);`
+
+	rows, err := db.QueryContext(ctx, query, pq.Array(modulePaths))
+	if err != nil {
+		return nil, fmt.Errorf("db.QueryContext(ctx, %q, %v): %v", query, modulePaths, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var modulePath string
+		var r Retraction
+		if err := rows.Scan(&modulePath, &r.Low, &r.High, &r.Rationale); err != nil {
+			return nil, fmt.Errorf("row.Scan(): %v", err)
+		}
+		byModule[modulePath] = append(byModule[modulePath], r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err(): %v", err)
+	}
+	return byModule, nil
+}
+
+// InsertRetractions records the retraction ranges declared by a module's
+// go.mod `retract` directives, replacing any previously recorded for
+// modulePath. This is called during worker ingest, once per module version
+// processed, after the go.mod has been parsed.
+//
+// The delete and inserts run in a single transaction, so a failure partway
+// through leaves the previously recorded retractions in place rather than
+// losing them.
+func (db *DB) InsertRetractions(ctx context.Context, modulePath string, retracts []Retraction) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("db.BeginTx(ctx, nil): %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM retractions WHERE module_path = $1;`, modulePath); err != nil {
+		return fmt.Errorf("tx.ExecContext(ctx, %q): %v", modulePath, err)
+	}
+	for _, r := range retracts {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO retractions (module_path, low, high, rationale) VALUES ($1, $2, $3, $4);`,
+			modulePath, r.Low, r.High, r.Rationale); err != nil {
+			return fmt.Errorf("tx.ExecContext(ctx, %q, %q, %q): %v", modulePath, r.Low, r.High, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// isRetracted reports whether version falls within any of rets, using
+// semver precedence rather than lexicographic comparison, and returns the
+// rationale of the first matching range.
+func isRetracted(version string, rets []Retraction) (bool, string) {
+	for _, r := range rets {
+		if semver.Compare(version, r.Low) >= 0 && semver.Compare(version, r.High) <= 0 {
+			return true, r.Rationale
+		}
+	}
+	return false, ""
+}
+
+// seriesMajorMinor parses a version such as "v1.2.3" into its major and
+// minor numbers, for use in a major.minor series filter.
+func seriesMajorMinor(version string) (major, minor int, err error) {
+	series := strings.TrimPrefix(semver.MajorMinor(version), "v")
+	if series == "" {
+		return 0, 0, fmt.Errorf("invalid version %q", version)
+	}
+	parts := strings.SplitN(series, ".", 2)
+	major, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid version %q: %v", version, err)
+	}
+	if len(parts) == 2 {
+		minor, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid version %q: %v", version, err)
+		}
+	}
+	return major, minor, nil
+}
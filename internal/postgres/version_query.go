@@ -0,0 +1,303 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"golang.org/x/discovery/internal"
+	"golang.org/x/discovery/internal/derrors"
+	"golang.org/x/mod/semver"
+)
+
+// versionQueryKind identifies the shape of a parsed version query.
+type versionQueryKind int
+
+const (
+	versionQueryLatest versionQueryKind = iota
+	versionQueryUpgrade
+	versionQueryPatch
+	versionQueryPrefix
+	versionQueryExact
+	versionQueryCompare
+)
+
+// versionQuery is the parsed form of a version query string, following the
+// grammar accepted by the go command's module queries (see
+// cmd/go/internal/modload.Query and `go help goproxy`).
+type versionQuery struct {
+	kind versionQueryKind
+
+	// semverOp is one of "<", "<=", ">", ">=", set when kind is
+	// versionQueryCompare.
+	semverOp string
+
+	// majorOnly is a bare major prefix, e.g. "v1", set when kind is
+	// versionQueryPrefix and the query has no minor component.
+	majorOnly string
+
+	// minorOnly is a bare major.minor prefix, e.g. "v1.2", set when kind is
+	// versionQueryPrefix and the query has a minor component.
+	minorOnly string
+
+	// exact is a fully-qualified semantic version, set when kind is
+	// versionQueryExact or versionQueryCompare.
+	exact string
+}
+
+var (
+	compareQueryRE = regexp.MustCompile(`^(<=|>=|<|>)(v\d+\.\d+\.\d+.*)$`)
+	prefixQueryRE  = regexp.MustCompile(`^v(0|[1-9]\d*)(?:\.(0|[1-9]\d*))?$`)
+	semverPartsRE  = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+.*)?$`)
+)
+
+// parseVersionQuery parses query according to the version-query grammar
+// used by the go command: the literals "latest", "upgrade", and "patch", a
+// bare major or major.minor prefix such as "v1" or "v1.2", an exact version
+// such as "v1.2.3", and the comparison forms "<v1.2.3", "<=v1.2.3",
+// ">v1.2.3", and ">=v1.2.3".
+func parseVersionQuery(query string) (*versionQuery, error) {
+	switch query {
+	case "latest":
+		return &versionQuery{kind: versionQueryLatest}, nil
+	case "upgrade":
+		return &versionQuery{kind: versionQueryUpgrade}, nil
+	case "patch":
+		return &versionQuery{kind: versionQueryPatch}, nil
+	}
+	if m := compareQueryRE.FindStringSubmatch(query); m != nil {
+		if !semver.IsValid(m[2]) {
+			return nil, fmt.Errorf("invalid version query %q: %q is not a semantic version", query, m[2])
+		}
+		return &versionQuery{kind: versionQueryCompare, semverOp: m[1], exact: m[2]}, nil
+	}
+	if m := prefixQueryRE.FindStringSubmatch(query); m != nil {
+		if m[2] == "" {
+			return &versionQuery{kind: versionQueryPrefix, majorOnly: "v" + m[1]}, nil
+		}
+		return &versionQuery{kind: versionQueryPrefix, minorOnly: "v" + m[1] + "." + m[2]}, nil
+	}
+	if semver.IsValid(query) {
+		return &versionQuery{kind: versionQueryExact, exact: query}, nil
+	}
+	return nil, fmt.Errorf("invalid version query %q", query)
+}
+
+// splitSemver breaks a full semantic version such as "v1.2.3-rc.1" into its
+// numeric major/minor/patch and its raw prerelease string.
+func splitSemver(v string) (major, minor, patch int, prerelease string, err error) {
+	m := semverPartsRE.FindStringSubmatch(v)
+	if m == nil {
+		return 0, 0, 0, "", fmt.Errorf("invalid semantic version %q", v)
+	}
+	major, _ = strconv.Atoi(m[1])
+	minor, _ = strconv.Atoi(m[2])
+	patch, _ = strconv.Atoi(m[3])
+	return major, minor, patch, m[4], nil
+}
+
+// GetPackageByQuery returns the package at path whose version best satisfies
+// query, following the version-query grammar used by the go command: the
+// literals "latest" and "upgrade", a bare major or major.minor prefix, an
+// exact version, and the comparison forms "<v1.2.3", "<=v1.2.3", ">v1.2.3",
+// and ">=v1.2.3".
+//
+// The "patch" query is not supported by GetPackageByQuery, since it needs a
+// current version to anchor its major.minor series; use
+// GetPackageByQueryFrom instead.
+func (db *DB) GetPackageByQuery(ctx context.Context, path, query string) (*internal.VersionedPackage, error) {
+	return db.GetPackageByQueryFrom(ctx, path, query, "")
+}
+
+// GetPackageByQueryFrom is like GetPackageByQuery, but also accepts the
+// "patch" query and an "upgrade" query anchored at current: "patch" returns
+// the latest version in the same major.minor series as current, and
+// "upgrade" returns current itself if current is already at least as new as
+// "latest".
+func (db *DB) GetPackageByQueryFrom(ctx context.Context, path, query, current string) (*internal.VersionedPackage, error) {
+	if path == "" || query == "" {
+		return nil, derrors.InvalidArgument("path and query cannot be empty")
+	}
+	vq, err := parseVersionQuery(query)
+	if err != nil {
+		return nil, derrors.InvalidArgument(err.Error())
+	}
+	if vq.kind == versionQueryPatch && current == "" {
+		return nil, derrors.InvalidArgument(`version query "patch" requires a current version`)
+	}
+
+	version, err := db.resolveVersionQuery(ctx, path, vq, current)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetPackage(ctx, path, version)
+}
+
+// seriesBound restricts a version-query SQL lookup to a major, or
+// major.minor, series.
+type seriesBound struct {
+	major, minor       int
+	hasMajor, hasMinor bool
+}
+
+// clause renders bound as a SQL WHERE fragment referencing numbered
+// placeholders starting at paramOffset+1, along with the corresponding
+// argument list.
+func (b seriesBound) clause(paramOffset int) (string, []interface{}) {
+	switch {
+	case !b.hasMajor:
+		return "", nil
+	case !b.hasMinor:
+		return fmt.Sprintf("AND v.major = $%d", paramOffset+1), []interface{}{b.major}
+	default:
+		return fmt.Sprintf("AND v.major = $%d AND v.minor = $%d", paramOffset+1, paramOffset+2),
+			[]interface{}{b.major, b.minor}
+	}
+}
+
+// resolveVersionQuery picks the version of the package at path that
+// satisfies vq, translating each query kind into a WHERE clause over
+// versions.major/minor/patch/prerelease_sort plus an ORDER BY ... LIMIT 1,
+// so the database does the filtering and ranking rather than this package
+// fetching every known version.
+func (db *DB) resolveVersionQuery(ctx context.Context, path string, vq *versionQuery, current string) (string, error) {
+	switch vq.kind {
+	case versionQueryExact:
+		v, ok, err := db.queryVersion(ctx, path, "AND v.version = $2", []interface{}{vq.exact}, "v.major DESC")
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", derrors.NotFound(fmt.Sprintf("version %s not found", vq.exact))
+		}
+		return v, nil
+
+	case versionQueryLatest:
+		return db.latestVersion(ctx, path, seriesBound{})
+
+	case versionQueryUpgrade:
+		latest, err := db.latestVersion(ctx, path, seriesBound{})
+		if err != nil {
+			return "", err
+		}
+		if current != "" && semver.Compare(current, latest) >= 0 {
+			return current, nil
+		}
+		return latest, nil
+
+	case versionQueryPatch:
+		major, minor, err := seriesMajorMinor(current)
+		if err != nil {
+			return "", derrors.InvalidArgument(err.Error())
+		}
+		return db.latestVersion(ctx, path, seriesBound{major: major, hasMajor: true, minor: minor, hasMinor: true})
+
+	case versionQueryPrefix:
+		series, hasMinor := vq.majorOnly, false
+		if vq.minorOnly != "" {
+			series, hasMinor = vq.minorOnly, true
+		}
+		major, minor, err := seriesMajorMinor(series)
+		if err != nil {
+			return "", derrors.InvalidArgument(err.Error())
+		}
+		bound := seriesBound{major: major, hasMajor: true}
+		if hasMinor {
+			bound.minor, bound.hasMinor = minor, true
+		}
+		return db.latestVersion(ctx, path, bound)
+
+	case versionQueryCompare:
+		return db.compareVersion(ctx, path, vq.semverOp, vq.exact)
+	}
+	return "", fmt.Errorf("BUG: unhandled version query kind %v", vq.kind)
+}
+
+// latestVersion returns the newest version of the package at path, by
+// semver precedence, restricted to bound if set. It excludes prereleases
+// unless no release version satisfies bound, in which case a second query
+// considers prereleases too, matching the "latest" query's semantics.
+func (db *DB) latestVersion(ctx context.Context, path string, bound seriesBound) (string, error) {
+	where, args := bound.clause(1)
+	order := "v.major DESC, v.minor DESC, v.patch DESC, v.prerelease_sort DESC"
+
+	v, ok, err := db.queryVersion(ctx, path, where+" AND v.prerelease_sort = '"+noPrereleaseSort+"'", args, order)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return v, nil
+	}
+	v, ok, err = db.queryVersion(ctx, path, where, args, order)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", derrors.NotFound(fmt.Sprintf("no version of %s found", path))
+	}
+	return v, nil
+}
+
+// compareVersion returns, among the package's known versions, the one
+// closest to bound that satisfies op: the largest version satisfying "<" or
+// "<=", and the smallest version satisfying ">" or ">=".
+func (db *DB) compareVersion(ctx context.Context, path, op, bound string) (string, error) {
+	major, minor, patch, prerelease, err := splitSemver(bound)
+	if err != nil {
+		return "", fmt.Errorf("BUG: %v", err)
+	}
+	boundSort := prereleaseSortKey(prerelease)
+
+	order := "v.major DESC, v.minor DESC, v.patch DESC, v.prerelease_sort DESC"
+	if op == ">" || op == ">=" {
+		order = "v.major ASC, v.minor ASC, v.patch ASC, v.prerelease_sort ASC"
+	}
+	where := fmt.Sprintf("AND (v.major, v.minor, v.patch, v.prerelease_sort) %s ($2, $3, $4, $5)", op)
+
+	v, ok, err := db.queryVersion(ctx, path, where, []interface{}{major, minor, patch, boundSort}, order)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", derrors.NotFound(fmt.Sprintf("no version %s%s found", op, bound))
+	}
+	return v, nil
+}
+
+// queryVersion runs a single SELECT ... LIMIT 1 for the first version, by
+// order, of the package at path satisfying extraWhere, reporting ok=false
+// rather than an error when nothing matches.
+func (db *DB) queryVersion(ctx context.Context, path, extraWhere string, extraArgs []interface{}, order string) (version string, ok bool, err error) {
+	query := fmt.Sprintf(`
+		SELECT
+			v.version
+		FROM
+			versions v
+		INNER JOIN
+			vw_licensed_packages p
+		ON
+			p.module_path = v.module_path
+			AND p.version = v.version
+		WHERE
+			p.path = $1
+			%s
+		ORDER BY
+			%s
+		LIMIT 1;`, extraWhere, order)
+
+	args := append([]interface{}{path}, extraArgs...)
+	row := db.QueryRowContext(ctx, query, args...)
+	if err := row.Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("row.Scan(): %v", err)
+	}
+	return version, true, nil
+}